@@ -0,0 +1,55 @@
+package apperrors
+
+import (
+	"errors"
+)
+
+// Error kinds shared across packages. Callers decide how to react to a
+// failure with errors.Is against these instead of matching message text.
+var (
+	// ErrTransient marks failures that may succeed if retried (lost connection, timeout)
+	ErrTransient = errors.New("transient error")
+	// ErrPermanent marks failures that will fail again if retried as-is
+	ErrPermanent = errors.New("permanent error")
+	// ErrValidation marks input that was rejected before any work was done
+	ErrValidation = errors.New("validation error")
+	// ErrDownstream marks failures reported by or while talking to an external API
+	ErrDownstream = errors.New("downstream error")
+)
+
+// Error is an error tagged with one of the kinds above
+type Error struct {
+	Kind error
+	Msg  string
+	Err  error
+}
+
+// Wrap tags err with kind, prefixing its message with msg.
+// err may be nil when there is no underlying cause.
+func Wrap(kind error, msg string, err error) error {
+	return &Error{Kind: kind, Msg: msg, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Msg
+	}
+	return e.Msg + ": " + e.Err.Error()
+}
+
+// Unwrap exposes both the kind and the underlying cause to errors.Is/As
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// KindOf returns the kind attached to err, or nil if err carries none
+func KindOf(err error) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+
+	"github.com/okamoto/socket-to-api/internal/apperrors"
+	"github.com/sijms/go-ora/v2/network"
+)
+
+// Oracle error codes that succeed when the statement or transaction is re-run
+var transientOracleCodes = map[int]bool{
+	60:    true, // ORA-00060: deadlock detected while waiting for resource
+	8177:  true, // ORA-08177: can't serialize access for this transaction
+	12516: true, // ORA-12516: listener could not find available handler
+	12520: true, // ORA-12520: listener could not find available handler for requested type of server
+}
+
+// Oracle error codes meaning the session or instance went away; a new
+// connection may reach a surviving node
+var lostSessionOracleCodes = map[int]bool{
+	1012:  true, // ORA-01012: not logged on
+	1033:  true, // ORA-01033: ORACLE initialization or shutdown in progress
+	1034:  true, // ORA-01034: ORACLE not available
+	1089:  true, // ORA-01089: immediate shutdown or close in progress
+	3113:  true, // ORA-03113: end-of-file on communication channel
+	3114:  true, // ORA-03114: not connected to ORACLE
+	3135:  true, // ORA-03135: connection lost contact
+	12528: true, // ORA-12528: listener: all appropriate instances are blocking new connections
+	12537: true, // ORA-12537: TNS:connection closed
+}
+
+// ErrorKind reports whether a database error is worth retrying.
+// Lost connections, refused connections, timeouts, deadlocks and
+// serialization failures are transient; everything else (constraint
+// violations, bad SQL, no rows, an unresolvable DB_HOST) is permanent.
+func ErrorKind(err error) error {
+	var oraErr *network.OracleError
+	switch {
+	case ConnectionLost(err), errors.Is(err, syscall.ECONNREFUSED):
+		return apperrors.ErrTransient
+	case errors.As(err, &oraErr) && transientOracleCodes[oraErr.ErrCode]:
+		return apperrors.ErrTransient
+	default:
+		return apperrors.ErrPermanent
+	}
+}
+
+// ConnectionLost reports whether err means the connection broke or stopped
+// answering mid-call, so the server may or may not have acted on the request.
+// A deadlock or a rejected statement is a definite failure and returns false.
+func ConnectionLost(err error) bool {
+	var netErr net.Error
+	var oraErr *network.OracleError
+	// Socket-level checks come first so a reset stays a lost connection even
+	// when the driver also attaches an Oracle error to it
+	switch {
+	case errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, network.ErrConnReset),
+		errors.Is(err, io.EOF),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.As(err, &netErr) && netErr.Timeout(): // includes context.DeadlineExceeded
+		return true
+	case errors.As(err, &oraErr):
+		return lostSessionOracleCodes[oraErr.ErrCode]
+	default:
+		return false
+	}
+}
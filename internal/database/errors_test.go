@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/okamoto/socket-to-api/internal/apperrors"
+	"github.com/sijms/go-ora/v2/network"
+)
+
+func TestErrorKind(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantKind error
+		wantLost bool
+	}{
+		{"ORA-00060 deadlock", network.NewOracleError(60), apperrors.ErrTransient, false},
+		{"ORA-08177 serialization", network.NewOracleError(8177), apperrors.ErrTransient, false},
+		{"ORA-12516 listener busy", network.NewOracleError(12516), apperrors.ErrTransient, false},
+		{"ORA-03113 end-of-file", network.NewOracleError(3113), apperrors.ErrTransient, true},
+		{"ORA-03135 lost contact", fmt.Errorf("exec: %w", network.NewOracleError(3135)), apperrors.ErrTransient, true},
+		{"ORA-00001 unique constraint", network.NewOracleError(1), apperrors.ErrPermanent, false},
+		{"ORA-00942 no such table", network.NewOracleError(942), apperrors.ErrPermanent, false},
+		{"bad conn", driver.ErrBadConn, apperrors.ErrTransient, true},
+		{"go-ora conn reset", network.ErrConnReset, apperrors.ErrTransient, true},
+		{"EOF", fmt.Errorf("read: %w", io.EOF), apperrors.ErrTransient, true},
+		{"ECONNRESET", &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, apperrors.ErrTransient, true},
+		{"ECONNREFUSED", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, apperrors.ErrTransient, false},
+		{"dial timeout", &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}, apperrors.ErrTransient, true},
+		{"context deadline", context.DeadlineExceeded, apperrors.ErrTransient, true},
+		{"DNS failure", &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "db.invalid", IsNotFound: true}}, apperrors.ErrPermanent, false},
+		{"no rows", sql.ErrNoRows, apperrors.ErrPermanent, false},
+		{"other error", errors.New("boom"), apperrors.ErrPermanent, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorKind(tt.err); got != tt.wantKind {
+				t.Errorf("ErrorKind(%v) = %v, want %v", tt.err, got, tt.wantKind)
+			}
+			if got := ConnectionLost(tt.err); got != tt.wantLost {
+				t.Errorf("ConnectionLost(%v) = %v, want %v", tt.err, got, tt.wantLost)
+			}
+		})
+	}
+}
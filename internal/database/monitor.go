@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// MonitorConfig controls how Monitor decides the database is down
+type MonitorConfig struct {
+	// Interval between pings while the database is healthy (default 10s)
+	Interval time.Duration
+	// PingTimeout bounds each ping, so a host that stops answering without
+	// closing the connection counts as a failure (default 5s)
+	PingTimeout time.Duration
+	// FailureThreshold is the number of consecutive failed pings before
+	// the database is reported down (default 1)
+	FailureThreshold int
+	// OnStateChange is called with false when the database goes down and
+	// with true when it comes back, so callers can pause and resume work
+	OnStateChange func(healthy bool)
+}
+
+// Monitor pings db until ctx is cancelled and reports up/down transitions.
+// While the database is down it pings with exponential backoff; the sqlx
+// pool opens fresh connections (following FailoverHosts) once a ping succeeds.
+// It is meant for long-running callers; main.go's one-shot POC exits before
+// the first ping and does not start it.
+func Monitor(ctx context.Context, db *sqlx.DB, cfg MonitorConfig) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	if cfg.PingTimeout <= 0 {
+		cfg.PingTimeout = 5 * time.Second
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 1
+	}
+
+	healthy := true
+	failures := 0
+	wait := cfg.Interval
+	backoff := initialBackoff
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, cfg.PingTimeout)
+		err := db.PingContext(pingCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err == nil {
+			if !healthy {
+				log.Printf("✓ Database reachable again after %d failed pings", failures)
+				healthy = true
+				notify(cfg.OnStateChange, true)
+			}
+			failures = 0
+			wait = cfg.Interval
+			backoff = initialBackoff
+			continue
+		}
+
+		failures++
+		if healthy && failures >= cfg.FailureThreshold {
+			log.Printf("⚠ Database unreachable after %d failed pings: %v", failures, err)
+			healthy = false
+			notify(cfg.OnStateChange, false)
+		}
+		if !healthy {
+			wait = backoff
+			backoff = nextBackoff(backoff)
+		}
+	}
+}
+
+func notify(fn func(bool), healthy bool) {
+	if fn != nil {
+		fn(healthy)
+	}
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// flakyConnector hands out connections whose Ping succeeds, fails, or hangs
+// until its context is done, depending on the current mode
+type flakyConnector struct {
+	mode atomic.Value // "ok", "fail" or "hang"
+}
+
+func (c *flakyConnector) Connect(context.Context) (driver.Conn, error) { return flakyConn{c}, nil }
+func (c *flakyConnector) Driver() driver.Driver                        { return nil }
+
+type flakyConn struct{ c *flakyConnector }
+
+func (fc flakyConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fc flakyConn) Close() error                        { return nil }
+func (fc flakyConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (fc flakyConn) Ping(ctx context.Context) error {
+	switch fc.c.mode.Load() {
+	case "fail":
+		return driver.ErrBadConn
+	case "hang":
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func TestMonitorReportsTransitions(t *testing.T) {
+	for _, mode := range []string{"fail", "hang"} {
+		t.Run(mode, func(t *testing.T) {
+			conn := &flakyConnector{}
+			conn.mode.Store("ok")
+			db := sqlx.NewDb(sql.OpenDB(conn), "oracle")
+			defer db.Close()
+
+			changes := make(chan bool, 4)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go Monitor(ctx, db, MonitorConfig{
+				Interval:         time.Millisecond,
+				PingTimeout:      10 * time.Millisecond,
+				FailureThreshold: 2,
+				OnStateChange:    func(healthy bool) { changes <- healthy },
+			})
+
+			conn.mode.Store(mode)
+			if got := waitChange(t, changes); got {
+				t.Fatal("first state change reported healthy, want down")
+			}
+			conn.mode.Store("ok")
+			if got := waitChange(t, changes); !got {
+				t.Fatal("second state change reported down, want healthy")
+			}
+		})
+	}
+}
+
+func waitChange(t *testing.T, changes <-chan bool) bool {
+	t.Helper()
+	select {
+	case healthy := <-changes:
+		return healthy
+	case <-time.After(5 * time.Second):
+		t.Fatal("no state change reported")
+		return false
+	}
+}
@@ -0,0 +1,27 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+)
+
+// IsCertError reports whether err is a certificate trust failure that a
+// different CA pool might fix: an unknown authority, an invalid (expired,
+// not-yet-valid, wrong usage) certificate, or a reply to the handshake that
+// isn't TLS at all. It sees through any wrapping, including the *url.Error
+// from net/http.
+//
+// A host name mismatch is not a cert error, since no CA pool fixes it. Nor
+// is a plain HTTP reply: net/http replaces that tls.RecordHeaderError with
+// an untyped "server gave HTTP response to HTTPS client" error.
+func IsCertError(err error) bool {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		invalid          x509.CertificateInvalidError
+		recordHeader     tls.RecordHeaderError
+	)
+	return errors.As(err, &unknownAuthority) ||
+		errors.As(err, &invalid) ||
+		errors.As(err, &recordHeader)
+}
@@ -0,0 +1,85 @@
+package httpclient
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIsCertError(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok": true}`))
+	})
+	tlsSrv := httptest.NewTLSServer(handler)
+	defer tlsSrv.Close()
+	plainSrv := httptest.NewServer(handler)
+	defer plainSrv.Close()
+
+	// A listener that answers the handshake with bytes that are neither TLS nor HTTP
+	garbage, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer garbage.Close()
+	go func() {
+		for {
+			conn, err := garbage.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("\x00\x01\x02\x03\x04 not tls\n"))
+			conn.Close()
+		}
+	}()
+
+	embedded, err := NewHTTPClientWithEmbeddedCerts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	trusting, err := NewHTTPClientWithTLS(TLSOptions{CAFile: writeCAFile(t, tlsSrv)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		get  func() error
+		want bool
+	}{
+		{"unknown CA", func() error {
+			_, err := embedded.TestHTTPSRequest(tlsSrv.URL)
+			return err
+		}, true},
+		{"non-TLS reply", func() error {
+			_, err := trusting.TestHTTPSRequest("https://" + garbage.Addr().String())
+			return err
+		}, true},
+		{"host name mismatch", func() error {
+			_, err := trusting.TestHTTPSRequest(strings.Replace(tlsSrv.URL, "127.0.0.1", "localhost", 1))
+			return err
+		}, false},
+		{"plain HTTP server", func() error {
+			_, err := trusting.TestHTTPSRequest(strings.Replace(plainSrv.URL, "http://", "https://", 1))
+			return err
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.get()
+			if err == nil {
+				t.Fatal("request succeeded, want error")
+			}
+			if got := IsCertError(err); got != tt.want {
+				t.Errorf("IsCertError(%v) = %v, want %v", err, got, tt.want)
+			}
+		})
+	}
+
+	if IsCertError(nil) || IsCertError(errors.New("boom")) {
+		t.Error("IsCertError reported a cert error for nil or an unrelated error")
+	}
+}
@@ -0,0 +1,190 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/okamoto/socket-to-api/internal/apperrors"
+)
+
+// defaultReloadInterval is used by Run when no positive interval is given
+const defaultReloadInterval = time.Hour
+
+// CertManager keeps a CA bundle loaded from disk and swaps reloaded
+// versions into every client built from it, without restarting them
+type CertManager struct {
+	caFile    string
+	transport atomic.Pointer[http.Transport]
+	certs     atomic.Pointer[[]*x509.Certificate]
+
+	// mu serializes Reload; hash is the SHA-256 of the bundle last loaded
+	mu   sync.Mutex
+	hash [sha256.Size]byte
+
+	// warned holds certificates already reported as expiring; only Run touches it
+	warned map[[sha256.Size]byte]bool
+}
+
+// CertExpiry describes a trusted certificate close to its NotAfter date
+type CertExpiry struct {
+	Source   string
+	Subject  string
+	NotAfter time.Time
+
+	raw []byte
+}
+
+// NewCertManager loads caFile once; call Run to keep it refreshed
+func NewCertManager(caFile string) (*CertManager, error) {
+	m := &CertManager{caFile: caFile, warned: make(map[[sha256.Size]byte]bool)}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reload re-reads the CA bundle. On failure the previous pool stays active,
+// and an unchanged bundle keeps the current transport and its pooled connections.
+func (m *CertManager) Reload() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, err := os.ReadFile(m.caFile)
+	if err != nil {
+		return apperrors.Wrap(apperrors.ErrTransient, "failed to read CA bundle", err)
+	}
+	hash := sha256.Sum256(data)
+	if m.transport.Load() != nil && hash == m.hash {
+		return nil
+	}
+
+	certs := parseCerts(data)
+	if len(certs) == 0 {
+		return apperrors.Wrap(apperrors.ErrPermanent, "no certificates found in CA bundle "+m.caFile, nil)
+	}
+
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	m.certs.Store(&certs)
+	m.hash = hash
+
+	// New connections verify against the new pool; pooled ones drain off the old transport
+	if old := m.transport.Swap(newTransport(&tls.Config{RootCAs: pool})); old != nil {
+		old.CloseIdleConnections()
+	}
+	return nil
+}
+
+// Run reloads the bundle every interval and warns once about each
+// certificate expiring within warnWithin, until ctx is cancelled.
+// A zero or negative interval falls back to one hour.
+func (m *CertManager) Run(ctx context.Context, interval, warnWithin time.Duration) {
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.logExpiring(warnWithin)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Reload(); err != nil {
+				logger().Warn("CA bundle reload failed, keeping previous pool", "path", m.caFile, "reason", err)
+			}
+		}
+	}
+}
+
+// ExpiringWithin lists certificates from the managed bundle, the embedded
+// bundle and the system bundle that expire within d
+func (m *CertManager) ExpiringWithin(d time.Duration) []CertExpiry {
+	deadline := time.Now().Add(d)
+	var expiring []CertExpiry
+
+	check := func(source string, certs []*x509.Certificate) {
+		for _, cert := range certs {
+			if cert.NotAfter.Before(deadline) {
+				expiring = append(expiring, CertExpiry{
+					Source:   source,
+					Subject:  cert.Subject.String(),
+					NotAfter: cert.NotAfter,
+					raw:      cert.Raw,
+				})
+			}
+		}
+	}
+
+	check(m.caFile, *m.certs.Load())
+	check("embedded", embeddedCerts())
+	for _, path := range systemCertPaths {
+		if data, err := os.ReadFile(path); err == nil {
+			check(path, parseCerts(data))
+			break
+		}
+	}
+	return expiring
+}
+
+// logExpiring warns about each expiring certificate the first time it is seen
+func (m *CertManager) logExpiring(within time.Duration) {
+	for _, e := range m.ExpiringWithin(within) {
+		key := sha256.Sum256(e.raw)
+		if m.warned[key] {
+			continue
+		}
+		m.warned[key] = true
+		logger().Warn("CA certificate nearing expiry", "subject", e.Subject, "source", e.Source, "not_after", e.NotAfter)
+	}
+}
+
+// RoundTrip sends req through the transport built from the latest bundle
+func (m *CertManager) RoundTrip(req *http.Request) (*http.Response, error) {
+	return m.transport.Load().RoundTrip(req)
+}
+
+// NewHTTPClientWithCertManager creates a client whose trusted CAs follow m
+func NewHTTPClientWithCertManager(m *CertManager) *HTTPClientWithTLS {
+	return &HTTPClientWithTLS{
+		client: &http.Client{
+			Transport: m,
+			Timeout:   10 * time.Second,
+		},
+	}
+}
+
+// embeddedCerts parses the embedded bundle on first use; it never changes at runtime
+var embeddedCerts = sync.OnceValue(func() []*x509.Certificate {
+	return parseCerts(embeddedCACerts)
+})
+
+// parseCerts decodes every certificate in a PEM bundle, skipping bad blocks
+func parseCerts(data []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCertManagerReloadKeepsTransportWhenUnchanged(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	caFile := writeCAFile(t, srv)
+	m, err := NewCertManager(caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := m.transport.Load()
+
+	if err := m.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if m.transport.Load() != before {
+		t.Error("Reload() replaced the transport although the bundle is unchanged")
+	}
+
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(caFile, append(data, embeddedCACerts...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if m.transport.Load() == before {
+		t.Error("Reload() kept the old transport after the bundle changed")
+	}
+}
+
+// writeShortLivedCA writes a self-signed CA certificate that expires after lifetime
+func writeShortLivedCA(t *testing.T, commonName string, lifetime time.Duration) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(lifetime),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCertManagerExpiringWithin(t *testing.T) {
+	const subject = "CN=short-lived test CA"
+	caFile := writeShortLivedCA(t, "short-lived test CA", time.Hour)
+	m, err := NewCertManager(caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := func(expiring []CertExpiry) bool {
+		for _, e := range expiring {
+			if e.Subject == subject && e.Source == caFile {
+				return true
+			}
+		}
+		return false
+	}
+	if !found(m.ExpiringWithin(24 * time.Hour)) {
+		t.Error("ExpiringWithin(24h) did not report the certificate expiring in 1h")
+	}
+	if found(m.ExpiringWithin(time.Minute)) {
+		t.Error("ExpiringWithin(1m) reported the certificate expiring in 1h")
+	}
+}
+
+func TestCertManagerWarnsOncePerCertificate(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { pkgLogger.Store(nil) })
+
+	m, err := NewCertManager(writeShortLivedCA(t, "short-lived test CA", time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.logExpiring(24 * time.Hour)
+	m.logExpiring(24 * time.Hour)
+
+	if n := strings.Count(buf.String(), "short-lived test CA"); n != 1 {
+		t.Errorf("expiring certificate logged %d times, want 1:\n%s", n, buf.String())
+	}
+}
+
+func TestCertManagerRunDefaultsInterval(t *testing.T) {
+	m, err := NewCertManager(writeShortLivedCA(t, "test CA", 24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Would panic in time.NewTicker without the default
+	m.Run(ctx, 0, time.Hour)
+}
@@ -1,11 +1,10 @@
 package httpclient
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 	"time"
+
+	"github.com/okamoto/socket-to-api/internal/apperrors"
 )
 
 type HTTPClient struct {
@@ -24,20 +23,13 @@ func NewHTTPClient() *HTTPClient {
 func (c *HTTPClient) TestHTTPSRequest(url string) (map[string]interface{}, error) {
 	resp, err := c.client.Get(url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make HTTPS request: %w", err)
+		return nil, apperrors.Wrap(apperrors.ErrDownstream, "failed to make HTTPS request", err)
 	}
-	defer resp.Body.Close()
+	defer closeBody(resp.Body)
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var result map[string]interface{}
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	if err := checkStatus(resp); err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return decodeJSON(resp, DefaultMaxResponseBytes)
 }
@@ -4,11 +4,10 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	_ "embed"
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 	"time"
+
+	"github.com/okamoto/socket-to-api/internal/apperrors"
 )
 
 //go:embed certs/broken-ca-cert.pem
@@ -28,22 +27,18 @@ func NewHTTPClientFallbackTest() (*HTTPClientFallbackTest, error) {
 	brokenPool := x509.NewCertPool()
 	brokenPool.AppendCertsFromPEM(brokenCACert)
 	client.brokenClient = &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{RootCAs: brokenPool},
-		},
-		Timeout: 10 * time.Second,
+		Transport: newTransport(&tls.Config{RootCAs: brokenPool}),
+		Timeout:   10 * time.Second,
 	}
 
 	// 2. Create client with WORKING embedded certs (will succeed)
 	workingPool := x509.NewCertPool()
 	if !workingPool.AppendCertsFromPEM(embeddedCACerts) {
-		return nil, fmt.Errorf("failed to load working CA certificates")
+		return nil, apperrors.Wrap(apperrors.ErrPermanent, "failed to load working CA certificates", nil)
 	}
 	client.workingClient = &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{RootCAs: workingPool},
-		},
-		Timeout: 10 * time.Second,
+		Transport: newTransport(&tls.Config{RootCAs: workingPool}),
+		Timeout:   10 * time.Second,
 	}
 
 	return client, nil
@@ -51,92 +46,42 @@ func NewHTTPClientFallbackTest() (*HTTPClientFallbackTest, error) {
 
 // TestFallbackMechanism demonstrates the fallback from broken to working certs
 func (c *HTTPClientFallbackTest) TestFallbackMechanism(url string) (map[string]interface{}, string, error) {
-	fmt.Println("\n  === Testing Fallback Mechanism ===")
-
 	// Step 1: Try with BROKEN certs (should fail)
-	fmt.Println("  [1/2] Attempting with BROKEN CA certificates...")
+	logger().Debug("attempting HTTPS request", "cert_source", "broken", "url", url)
 	result, err := c.makeRequest(c.brokenClient, url)
 	if err != nil {
-		fmt.Printf("  ✗ Expected failure with broken certs: %v\n", err)
-
 		// Check if it's a cert error
-		if isCertErrorFallbackTest(err) {
-			fmt.Println("  → Certificate error detected, falling back...")
+		if IsCertError(err) {
+			logger().Warn("cert source failed", "cert_source", "broken", "reason", err)
 
 			// Step 2: Fallback to WORKING certs (should succeed)
-			fmt.Println("  [2/2] Falling back to WORKING CA certificates...")
+			logger().Debug("attempting HTTPS request", "cert_source", "working", "url", url)
 			result, err = c.makeRequest(c.workingClient, url)
 			if err != nil {
-				return nil, "", fmt.Errorf("fallback also failed: %w", err)
+				return nil, "", apperrors.Wrap(apperrors.ErrDownstream, "fallback also failed", err)
 			}
-			fmt.Println("  ✓ SUCCESS! Fallback to working certs succeeded!")
+			logger().Info("cert source selected", "cert_source", "working", "attempts", 2)
 			return result, "working-certs-after-fallback", nil
 		}
 
-		return nil, "", fmt.Errorf("non-certificate error, cannot fallback: %w", err)
+		return nil, "", apperrors.Wrap(apperrors.ErrDownstream, "non-certificate error, cannot fallback", err)
 	}
 
 	// Unexpected: broken certs worked (shouldn't happen)
-	fmt.Println("  ⚠ Unexpected: broken certs succeeded!")
+	logger().Warn("broken CA certificates unexpectedly verified the server", "url", url)
 	return result, "broken-certs-unexpectedly-worked", nil
 }
 
 func (c *HTTPClientFallbackTest) makeRequest(client *http.Client, url string) (map[string]interface{}, error) {
 	resp, err := client.Get(url)
 	if err != nil {
-		return nil, err
+		return nil, apperrors.Wrap(apperrors.ErrDownstream, "request failed", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal: %w", err)
-	}
-
-	return result, nil
-}
+	defer closeBody(resp.Body)
 
-// isCertError checks if the error is related to certificate verification
-// (reusing the function from client_smart_fallback.go)
-func isCertErrorFallbackTest(err error) bool {
-	if err == nil {
-		return false
-	}
-	errStr := err.Error()
-	certErrors := []string{
-		"certificate",
-		"x509",
-		"tls",
-		"unknown authority",
-		"certificate signed by unknown authority",
-		"certificate has expired",
-		"certificate is not valid",
-	}
-
-	for _, certErr := range certErrors {
-		if containsStr(errStr, certErr) {
-			return true
-		}
+	if err := checkStatus(resp); err != nil {
+		return nil, err
 	}
-	return false
-}
-
-func containsStr(s, substr string) bool {
-	return len(s) >= len(substr) &&
-		(s == substr || len(s) > len(substr) &&
-		indexOfStr(s, substr) >= 0)
-}
 
-func indexOfStr(s, substr string) int {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return i
-		}
-	}
-	return -1
+	return decodeJSON(resp, DefaultMaxResponseBytes)
 }
@@ -3,17 +3,26 @@ package httpclient
 import (
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"time"
+
+	"github.com/okamoto/socket-to-api/internal/apperrors"
 )
 
 // Use the embedded certs from client_with_embedded_certs.go
 // (embeddedCACerts is already declared in that file)
 
+// Common system CA certificate paths (Linux/Unix)
+var systemCertPaths = []string{
+	"/etc/ssl/certs/ca-certificates.crt",                // Debian/Ubuntu/Alpine
+	"/etc/pki/tls/certs/ca-bundle.crt",                  // Fedora/RHEL/CentOS
+	"/etc/ssl/ca-bundle.pem",                            // OpenSUSE
+	"/etc/pki/tls/cacert.pem",                           // OpenELEC
+	"/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem", // CentOS/RHEL 7+
+	"/etc/ssl/cert.pem",                                 // Alpine (alternative)
+}
+
 type HTTPClientHybrid struct {
 	client *http.Client
 }
@@ -23,21 +32,10 @@ type HTTPClientHybrid struct {
 // 2. Falls back to embedded CA certificates if system certs aren't available
 // 3. If both are available, it uses both (system certs take precedence)
 func NewHTTPClientHybrid() (*HTTPClientHybrid, error) {
-	// Start with system cert pool (may be nil on systems without certs)
-	certPool, err := x509.SystemCertPool()
-	if err != nil || certPool == nil {
-		// System certs not available, create empty pool
-		fmt.Println("System CA certificates not available, using embedded certs only")
-		certPool = x509.NewCertPool()
-	} else {
-		fmt.Println("Using system CA certificates as base")
-	}
-
-	// Always append embedded certs as fallback/supplement
-	if ok := certPool.AppendCertsFromPEM(embeddedCACerts); !ok {
-		return nil, fmt.Errorf("failed to append embedded CA certificates")
+	certPool, err := hybridCertPool()
+	if err != nil {
+		return nil, err
 	}
-	fmt.Println("Added embedded CA certificates to pool")
 
 	// Create custom TLS config with hybrid cert pool
 	tlsConfig := &tls.Config{
@@ -45,9 +43,7 @@ func NewHTTPClientHybrid() (*HTTPClientHybrid, error) {
 	}
 
 	// Create HTTP client with custom transport
-	transport := &http.Transport{
-		TLSClientConfig: tlsConfig,
-	}
+	transport := newTransport(tlsConfig)
 
 	return &HTTPClientHybrid{
 		client: &http.Client{
@@ -57,27 +53,38 @@ func NewHTTPClientHybrid() (*HTTPClientHybrid, error) {
 	}, nil
 }
 
+// hybridCertPool returns the system CA pool with the embedded certs appended.
+// Without system certs it falls back to the embedded certs alone.
+func hybridCertPool() (*x509.CertPool, error) {
+	// Start with system cert pool (may be nil on systems without certs)
+	certPool, err := x509.SystemCertPool()
+	if err != nil || certPool == nil {
+		// System certs not available, create empty pool
+		logger().Warn("system CA certificates not available, using embedded certs only")
+		certPool = x509.NewCertPool()
+	} else {
+		logger().Debug("using system CA certificates as base")
+	}
+
+	// Always append embedded certs as fallback/supplement
+	if ok := certPool.AppendCertsFromPEM(embeddedCACerts); !ok {
+		return nil, apperrors.Wrap(apperrors.ErrPermanent, "failed to append embedded CA certificates", nil)
+	}
+	logger().Debug("added embedded CA certificates to pool")
+	return certPool, nil
+}
+
 // NewHTTPClientSystemWithFallback creates a client that explicitly checks system paths
 // and falls back to embedded certs
 func NewHTTPClientSystemWithFallback() (*HTTPClientHybrid, error) {
 	certPool := x509.NewCertPool()
 	systemCertsLoaded := false
 
-	// Common system CA certificate paths (Linux/Unix)
-	certPaths := []string{
-		"/etc/ssl/certs/ca-certificates.crt",                // Debian/Ubuntu/Alpine
-		"/etc/pki/tls/certs/ca-bundle.crt",                  // Fedora/RHEL/CentOS
-		"/etc/ssl/ca-bundle.pem",                            // OpenSUSE
-		"/etc/pki/tls/cacert.pem",                           // OpenELEC
-		"/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem", // CentOS/RHEL 7+
-		"/etc/ssl/cert.pem",                                 // Alpine (alternative)
-	}
-
 	// Try to load from system paths
-	for _, path := range certPaths {
+	for _, path := range systemCertPaths {
 		if certs, err := os.ReadFile(path); err == nil {
 			if ok := certPool.AppendCertsFromPEM(certs); ok {
-				fmt.Printf("✓ Loaded system CA certificates from: %s\n", path)
+				logger().Info("loaded system CA certificates", "path", path)
 				systemCertsLoaded = true
 				break
 			}
@@ -85,17 +92,17 @@ func NewHTTPClientSystemWithFallback() (*HTTPClientHybrid, error) {
 	}
 
 	if !systemCertsLoaded {
-		fmt.Println("⚠ System CA certificates not found in standard paths")
+		logger().Warn("system CA certificates not found in standard paths")
 	}
 
 	// Always add embedded certs as fallback
 	if ok := certPool.AppendCertsFromPEM(embeddedCACerts); !ok {
-		return nil, fmt.Errorf("failed to append embedded CA certificates")
+		return nil, apperrors.Wrap(apperrors.ErrPermanent, "failed to append embedded CA certificates", nil)
 	}
 	if systemCertsLoaded {
-		fmt.Println("✓ Added embedded CA certificates as supplement")
+		logger().Debug("added embedded CA certificates as supplement")
 	} else {
-		fmt.Println("✓ Using embedded CA certificates as primary source")
+		logger().Info("using embedded CA certificates as primary source")
 	}
 
 	// Create custom TLS config
@@ -103,9 +110,7 @@ func NewHTTPClientSystemWithFallback() (*HTTPClientHybrid, error) {
 		RootCAs: certPool,
 	}
 
-	transport := &http.Transport{
-		TLSClientConfig: tlsConfig,
-	}
+	transport := newTransport(tlsConfig)
 
 	return &HTTPClientHybrid{
 		client: &http.Client{
@@ -119,20 +124,13 @@ func NewHTTPClientSystemWithFallback() (*HTTPClientHybrid, error) {
 func (c *HTTPClientHybrid) TestHTTPSRequest(url string) (map[string]interface{}, error) {
 	resp, err := c.client.Get(url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make HTTPS request: %w", err)
+		return nil, apperrors.Wrap(apperrors.ErrDownstream, "failed to make HTTPS request", err)
 	}
-	defer resp.Body.Close()
+	defer closeBody(resp.Body)
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var result map[string]interface{}
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	if err := checkStatus(resp); err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return decodeJSON(resp, DefaultMaxResponseBytes)
 }
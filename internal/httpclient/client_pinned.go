@@ -0,0 +1,160 @@
+package httpclient
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/okamoto/socket-to-api/internal/apperrors"
+)
+
+// TLSOptions describes how to trust one specific endpoint
+type TLSOptions struct {
+	// CAFile is a PEM bundle to trust instead of system + embedded certs (private PKI)
+	CAFile string
+	// PinnedSPKI lists base64 SHA-256 hashes of SubjectPublicKeyInfo; when set,
+	// at least one certificate in the verified chain must match. Malformed
+	// pins are rejected by NewHTTPClientWithTLS with apperrors.ErrValidation.
+	PinnedSPKI []string
+	// PinnedLeaf lists base64 SHA-256 hashes of the whole DER leaf certificate;
+	// when set, the server's leaf must match one of them. Unlike PinnedSPKI,
+	// these break when the certificate is reissued, even with the same key.
+	PinnedLeaf []string
+	// ServerName overrides the SNI and verification host name
+	ServerName string
+	// MaxResponseBytes caps the decoded response body (default DefaultMaxResponseBytes)
+	MaxResponseBytes int64
+
+	// MaxConnsPerHost limits dialing, active and idle connections per host (default unlimited)
+	MaxConnsPerHost int
+	// DisableKeepAlives opens a new connection for every request
+	DisableKeepAlives bool
+	// DialTimeout bounds establishing the TCP connection (default 30s)
+	DialTimeout time.Duration
+	// ResponseHeaderTimeout bounds the wait for response headers once the
+	// request is written (default none; the client's 10s timeout still applies)
+	ResponseHeaderTimeout time.Duration
+	// ExpectContinueTimeout bounds the wait for "100 Continue" (default 1s)
+	ExpectContinueTimeout time.Duration
+}
+
+type HTTPClientWithTLS struct {
+	client           *http.Client
+	maxResponseBytes int64
+}
+
+// NewHTTPClientWithTLS creates a client for an endpoint with its own trust settings.
+// Without a CAFile it trusts the same system + embedded pool as NewHTTPClientHybrid.
+func NewHTTPClientWithTLS(opts TLSOptions) (*HTTPClientWithTLS, error) {
+	spkiPins, err := parsePins("SPKI", opts.PinnedSPKI)
+	if err != nil {
+		return nil, err
+	}
+	leafPins, err := parsePins("leaf", opts.PinnedLeaf)
+	if err != nil {
+		return nil, err
+	}
+	certPool, err := opts.certPool()
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		RootCAs:    certPool,
+		ServerName: opts.ServerName,
+	}
+	if len(spkiPins) > 0 || len(leafPins) > 0 {
+		tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyPins(cs, spkiPins, leafPins)
+		}
+	}
+
+	transport := newTransport(tlsConfig)
+	opts.tuneTransport(transport)
+
+	return &HTTPClientWithTLS{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   10 * time.Second,
+		},
+		maxResponseBytes: opts.MaxResponseBytes,
+	}, nil
+}
+
+// parsePins validates pins up front, so a typo or a pin in the wrong
+// format fails here instead of rejecting every TLS connection later
+func parsePins(kind string, list []string) (map[string]bool, error) {
+	pins := make(map[string]bool, len(list))
+	for _, pin := range list {
+		sum, err := base64.StdEncoding.DecodeString(pin)
+		if err != nil || len(sum) != sha256.Size {
+			return nil, apperrors.Wrap(apperrors.ErrValidation, "pinned "+kind+" "+pin+" is not a base64 SHA-256 hash", err)
+		}
+		pins[pin] = true
+	}
+	return pins, nil
+}
+
+// certPool loads CAFile, or the system + embedded pool when no file is given
+func (o TLSOptions) certPool() (*x509.CertPool, error) {
+	if o.CAFile == "" {
+		return hybridCertPool()
+	}
+
+	certs, err := os.ReadFile(o.CAFile)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrPermanent, "failed to read CA file", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(certs) {
+		return nil, apperrors.Wrap(apperrors.ErrPermanent, "no certificates found in CA file "+o.CAFile, nil)
+	}
+	return pool, nil
+}
+
+// verifyPins runs after normal chain verification. With SPKI pins, some
+// certificate in a verified chain must carry a pinned public key; with
+// leaf pins, the server's own certificate must be pinned.
+func verifyPins(cs tls.ConnectionState, spkiPins, leafPins map[string]bool) error {
+	if len(leafPins) > 0 {
+		if len(cs.PeerCertificates) == 0 || !leafPins[pinOf(cs.PeerCertificates[0].Raw)] {
+			return apperrors.Wrap(apperrors.ErrPermanent, "leaf certificate does not match a pinned hash for "+cs.ServerName, nil)
+		}
+	}
+	if len(spkiPins) == 0 {
+		return nil
+	}
+	for _, chain := range cs.VerifiedChains {
+		for _, cert := range chain {
+			if spkiPins[pinOf(cert.RawSubjectPublicKeyInfo)] {
+				return nil
+			}
+		}
+	}
+	return apperrors.Wrap(apperrors.ErrPermanent, "no certificate in chain matches a pinned public key for "+cs.ServerName, nil)
+}
+
+// pinOf returns the base64 SHA-256 hash used in PinnedSPKI and PinnedLeaf
+func pinOf(der []byte) string {
+	sum := sha256.Sum256(der)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// TestHTTPSRequest tests making an HTTPS request using the endpoint's TLS options
+func (c *HTTPClientWithTLS) TestHTTPSRequest(url string) (map[string]interface{}, error) {
+	resp, err := c.client.Get(url)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrDownstream, "failed to make HTTPS request", err)
+	}
+	defer closeBody(resp.Body)
+
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+
+	return decodeJSON(resp, c.maxResponseBytes)
+}
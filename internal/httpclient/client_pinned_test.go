@@ -0,0 +1,91 @@
+package httpclient
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/okamoto/socket-to-api/internal/apperrors"
+)
+
+// spkiPin returns the base64 SHA-256 pin of the test server's public key
+func spkiPin(srv *httptest.Server) string {
+	sum := sha256.Sum256(srv.Certificate().RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// leafPin returns the base64 SHA-256 pin of the test server's certificate
+func leafPin(srv *httptest.Server) string {
+	sum := sha256.Sum256(srv.Certificate().Raw)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func TestHTTPClientWithTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer srv.Close()
+
+	otherPin := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+	// httptest's certificate is issued for example.com and 127.0.0.1
+	url := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+
+	tests := []struct {
+		name    string
+		opts    TLSOptions
+		url     string
+		wantErr string
+	}{
+		{"matching pin", TLSOptions{PinnedSPKI: []string{otherPin, spkiPin(srv)}}, srv.URL, ""},
+		{"no matching pin", TLSOptions{PinnedSPKI: []string{otherPin}}, srv.URL, "pinned public key"},
+		{"matching leaf pin", TLSOptions{PinnedLeaf: []string{otherPin, leafPin(srv)}}, srv.URL, ""},
+		{"no matching leaf pin", TLSOptions{PinnedLeaf: []string{otherPin}}, srv.URL, "leaf certificate"},
+		{"leaf pin given as SPKI pin", TLSOptions{PinnedSPKI: []string{leafPin(srv)}}, srv.URL, "pinned public key"},
+		{"matching leaf and SPKI pins", TLSOptions{PinnedLeaf: []string{leafPin(srv)}, PinnedSPKI: []string{spkiPin(srv)}}, srv.URL, ""},
+		{"host not in certificate", TLSOptions{}, url, "localhost"},
+		{"server name override", TLSOptions{ServerName: "example.com"}, url, ""},
+		{"server name override with pin", TLSOptions{ServerName: "example.com", PinnedSPKI: []string{spkiPin(srv)}}, url, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.CAFile = writeCAFile(t, srv)
+			client, err := NewHTTPClientWithTLS(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := client.TestHTTPSRequest(tt.url)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("TestHTTPSRequest() error = %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TestHTTPSRequest() error = %v", err)
+			}
+			if result["ok"] != true {
+				t.Errorf("TestHTTPSRequest() = %v, want ok=true", result)
+			}
+		})
+	}
+}
+
+func TestHTTPClientWithTLSRejectsMalformedPins(t *testing.T) {
+	sha1Pin := base64.StdEncoding.EncodeToString(make([]byte, 20))
+	hexPin := strings.Repeat("ab", sha256.Size)
+
+	for _, pin := range []string{"not base64!", sha1Pin, hexPin} {
+		for _, opts := range []TLSOptions{{PinnedSPKI: []string{pin}}, {PinnedLeaf: []string{pin}}} {
+			_, err := NewHTTPClientWithTLS(opts)
+			if !errors.Is(err, apperrors.ErrValidation) {
+				t.Errorf("NewHTTPClientWithTLS(%+v) error = %v, want %v", opts, err, apperrors.ErrValidation)
+			}
+		}
+	}
+}
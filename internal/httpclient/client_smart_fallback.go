@@ -3,12 +3,11 @@ package httpclient
 import (
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"time"
+
+	"github.com/okamoto/socket-to-api/internal/apperrors"
 )
 
 type HTTPClientSmartFallback struct {
@@ -25,37 +24,30 @@ func NewHTTPClientSmartFallback() (*HTTPClientSmartFallback, error) {
 	// 1. Create client with embedded certs only
 	embeddedPool := x509.NewCertPool()
 	if !embeddedPool.AppendCertsFromPEM(embeddedCACerts) {
-		return nil, fmt.Errorf("failed to load embedded CA certificates")
+		return nil, apperrors.Wrap(apperrors.ErrPermanent, "failed to load embedded CA certificates", nil)
 	}
 	client.embeddedClient = &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{RootCAs: embeddedPool},
-		},
-		Timeout: 10 * time.Second,
+		Transport: newTransport(&tls.Config{RootCAs: embeddedPool}),
+		Timeout:   10 * time.Second,
 	}
 
 	// 2. Create client with system certs only (if available)
 	systemPool, err := loadSystemCerts()
 	if err == nil && systemPool != nil {
 		client.systemClient = &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{RootCAs: systemPool},
-			},
-			Timeout: 10 * time.Second,
+			Transport: newTransport(&tls.Config{RootCAs: systemPool}),
+			Timeout:   10 * time.Second,
 		}
 	}
 
 	// 3. Create hybrid client (system + embedded)
-	hybridPool, _ := x509.SystemCertPool()
-	if hybridPool == nil {
-		hybridPool = x509.NewCertPool()
+	hybridPool, err := hybridCertPool()
+	if err != nil {
+		return nil, err
 	}
-	hybridPool.AppendCertsFromPEM(embeddedCACerts)
 	client.hybridClient = &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{RootCAs: hybridPool},
-		},
-		Timeout: 10 * time.Second,
+		Transport: newTransport(&tls.Config{RootCAs: hybridPool}),
+		Timeout:   10 * time.Second,
 	}
 
 	return client, nil
@@ -63,152 +55,104 @@ func NewHTTPClientSmartFallback() (*HTTPClientSmartFallback, error) {
 
 // loadSystemCerts tries to load system CA certificates from common paths
 func loadSystemCerts() (*x509.CertPool, error) {
-	certPaths := []string{
-		"/etc/ssl/certs/ca-certificates.crt",
-		"/etc/pki/tls/certs/ca-bundle.crt",
-		"/etc/ssl/ca-bundle.pem",
-		"/etc/pki/tls/cacert.pem",
-		"/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem",
-		"/etc/ssl/cert.pem",
-	}
-
 	pool := x509.NewCertPool()
-	for _, path := range certPaths {
+	for _, path := range systemCertPaths {
 		if certs, err := os.ReadFile(path); err == nil {
 			if pool.AppendCertsFromPEM(certs) {
 				return pool, nil
 			}
 		}
 	}
-	return nil, fmt.Errorf("no system certificates found")
+	return nil, apperrors.Wrap(apperrors.ErrPermanent, "no system certificates found", nil)
 }
 
-// TestHTTPSRequestWithSmartFallback tries multiple CA cert sources with intelligent fallback
-func (c *HTTPClientSmartFallback) TestHTTPSRequestWithSmartFallback(url string) (map[string]interface{}, error) {
-	var lastErr error
-
-	// Strategy 1: Try hybrid first (best chance of success)
-	fmt.Println("  → Attempting with hybrid CA certs (system + embedded)...")
-	result, err := c.makeRequest(c.hybridClient, url)
-	if err == nil {
-		fmt.Println("  ✓ Success with hybrid CA certs!")
-		return result, nil
-	}
-	lastErr = err
-	fmt.Printf("  ✗ Hybrid failed: %v\n", err)
+// certSource pairs a client with the name reported in logs and traces
+type certSource struct {
+	name   string
+	client *http.Client
+}
 
-	// Strategy 2: Try system certs only (if available)
+// sources returns the available cert sources in the order they are tried
+func (c *HTTPClientSmartFallback) sources() []certSource {
+	sources := []certSource{{name: "hybrid (system + embedded)", client: c.hybridClient}}
 	if c.systemClient != nil {
-		fmt.Println("  → Attempting with system CA certs only...")
-		result, err := c.makeRequest(c.systemClient, url)
-		if err == nil {
-			fmt.Println("  ✓ Success with system CA certs!")
-			return result, nil
-		}
-		lastErr = err
-		fmt.Printf("  ✗ System certs failed: %v\n", err)
-	}
-
-	// Strategy 3: Try embedded certs only (final fallback)
-	fmt.Println("  → Attempting with embedded CA certs only...")
-	result, err = c.makeRequest(c.embeddedClient, url)
-	if err == nil {
-		fmt.Println("  ✓ Success with embedded CA certs!")
-		return result, nil
+		sources = append(sources, certSource{name: "system only", client: c.systemClient})
 	}
-	fmt.Printf("  ✗ Embedded certs failed: %v\n", err)
+	return append(sources, certSource{name: "embedded only", client: c.embeddedClient})
+}
 
-	// All strategies failed
-	return nil, fmt.Errorf("all CA cert strategies failed, last error: %w", lastErr)
+// TestHTTPSRequestWithSmartFallback tries multiple CA cert sources with intelligent fallback
+func (c *HTTPClientSmartFallback) TestHTTPSRequestWithSmartFallback(url string) (map[string]interface{}, error) {
+	result, _, err := c.trySources(url, false)
+	return result, err
 }
 
 // makeRequest executes the HTTP request with the given client
 func (c *HTTPClientSmartFallback) makeRequest(client *http.Client, url string) (map[string]interface{}, error) {
 	resp, err := client.Get(url)
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, apperrors.Wrap(apperrors.ErrDownstream, "request failed", err)
 	}
+	defer closeBody(resp.Body)
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal: %w", err)
+	if err := checkStatus(resp); err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return decodeJSON(resp, DefaultMaxResponseBytes)
 }
 
 // TestHTTPSRequestWithRetry tries a request and falls back to different cert pools on TLS errors
 func (c *HTTPClientSmartFallback) TestHTTPSRequestWithRetry(url string) (result map[string]interface{}, certSource string, err error) {
-	// Try 1: Hybrid (system + embedded)
-	result, err = c.makeRequest(c.hybridClient, url)
-	if err == nil {
-		return result, "hybrid (system + embedded)", nil
-	}
-
-	// Check if it's a certificate verification error
-	if !isCertError(err) {
-		return nil, "", fmt.Errorf("non-certificate error: %w", err)
-	}
-
-	// Try 2: System only
-	if c.systemClient != nil {
-		result, err = c.makeRequest(c.systemClient, url)
-		if err == nil {
-			return result, "system only", nil
-		}
-	}
+	result, trace, err := c.TestHTTPSRequestWithTrace(url)
+	return result, trace.Selected(), err
+}
 
-	// Try 3: Embedded only
-	result, err = c.makeRequest(c.embeddedClient, url)
-	if err == nil {
-		return result, "embedded only", nil
-	}
+// TestHTTPSRequestWithTrace behaves like TestHTTPSRequestWithRetry and also
+// returns every cert source attempted, with the reason each one failed
+func (c *HTTPClientSmartFallback) TestHTTPSRequestWithTrace(url string) (map[string]interface{}, SelectionTrace, error) {
+	return c.trySources(url, true)
+}
 
-	return nil, "", fmt.Errorf("all certificate sources failed: %w", err)
+// CertAttempt records one try with a given CA cert source
+type CertAttempt struct {
+	Source string
+	Err    error
 }
 
-// isCertError checks if the error is related to certificate verification
-func isCertError(err error) bool {
-	if err == nil {
-		return false
-	}
-	errStr := err.Error()
-	// Check for common certificate-related error messages
-	certErrors := []string{
-		"certificate",
-		"x509",
-		"tls",
-		"unknown authority",
-		"certificate signed by unknown authority",
-		"certificate has expired",
-		"certificate is not valid",
-	}
+// SelectionTrace lists the cert sources tried for one request, in order
+type SelectionTrace []CertAttempt
 
-	for _, certErr := range certErrors {
-		if contains(errStr, certErr) {
-			return true
-		}
+// Selected returns the source that succeeded, or "" if none did
+func (t SelectionTrace) Selected() string {
+	if len(t) > 0 && t[len(t)-1].Err == nil {
+		return t[len(t)-1].Source
 	}
-	return false
+	return ""
 }
 
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) &&
-		(s == substr || len(s) > len(substr) &&
-		indexOf(s, substr) >= 0)
-}
+// trySources walks the cert sources until one succeeds. With certErrorsOnly,
+// a first failure that isn't certificate related stops the fallback, since
+// another CA pool can't fix it.
+func (c *HTTPClientSmartFallback) trySources(url string, certErrorsOnly bool) (map[string]interface{}, SelectionTrace, error) {
+	var trace SelectionTrace
+	var lastErr error
 
-func indexOf(s, substr string) int {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return i
+	for i, src := range c.sources() {
+		logger().Debug("attempting HTTPS request", "cert_source", src.name, "url", url)
+		result, err := c.makeRequest(src.client, url)
+		trace = append(trace, CertAttempt{Source: src.name, Err: err})
+		if err == nil {
+			logger().Info("cert source selected", "cert_source", src.name, "attempts", len(trace))
+			return result, trace, nil
 		}
+		lastErr = err
+
+		if certErrorsOnly && i == 0 && !IsCertError(err) {
+			return nil, trace, apperrors.Wrap(apperrors.ErrDownstream, "non-certificate error", err)
+		}
+		logger().Warn("cert source failed", "cert_source", src.name, "reason", err)
 	}
-	return -1
+
+	return nil, trace, apperrors.Wrap(apperrors.ErrDownstream, "all certificate sources failed", lastErr)
 }
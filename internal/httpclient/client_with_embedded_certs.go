@@ -4,11 +4,10 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	_ "embed"
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 	"time"
+
+	"github.com/okamoto/socket-to-api/internal/apperrors"
 )
 
 //go:embed certs/ca-certificates.crt
@@ -24,7 +23,7 @@ func NewHTTPClientWithEmbeddedCerts() (*HTTPClientWithEmbeddedCerts, error) {
 
 	// Append embedded CA certs to the pool
 	if !certPool.AppendCertsFromPEM(embeddedCACerts) {
-		return nil, fmt.Errorf("failed to append embedded CA certificates")
+		return nil, apperrors.Wrap(apperrors.ErrPermanent, "failed to append embedded CA certificates", nil)
 	}
 
 	// Create custom TLS config with embedded certs
@@ -33,9 +32,7 @@ func NewHTTPClientWithEmbeddedCerts() (*HTTPClientWithEmbeddedCerts, error) {
 	}
 
 	// Create HTTP client with custom transport
-	transport := &http.Transport{
-		TLSClientConfig: tlsConfig,
-	}
+	transport := newTransport(tlsConfig)
 
 	return &HTTPClientWithEmbeddedCerts{
 		client: &http.Client{
@@ -49,20 +46,13 @@ func NewHTTPClientWithEmbeddedCerts() (*HTTPClientWithEmbeddedCerts, error) {
 func (c *HTTPClientWithEmbeddedCerts) TestHTTPSRequest(url string) (map[string]interface{}, error) {
 	resp, err := c.client.Get(url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make HTTPS request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, apperrors.Wrap(apperrors.ErrDownstream, "failed to make HTTPS request", err)
 	}
+	defer closeBody(resp.Body)
 
-	var result map[string]interface{}
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	if err := checkStatus(resp); err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return decodeJSON(resp, DefaultMaxResponseBytes)
 }
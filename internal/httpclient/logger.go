@@ -0,0 +1,23 @@
+package httpclient
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+var pkgLogger atomic.Pointer[slog.Logger]
+
+// SetLogger routes httpclient diagnostics to l.
+// Chosen cert sources are logged at Info, fallbacks and expiring
+// certificates at Warn, and each individual attempt at Debug.
+func SetLogger(l *slog.Logger) {
+	pkgLogger.Store(l)
+}
+
+// logger returns the logger set with SetLogger, or slog's default
+func logger() *slog.Logger {
+	if l := pkgLogger.Load(); l != nil {
+		return l
+	}
+	return slog.Default()
+}
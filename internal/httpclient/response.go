@@ -0,0 +1,52 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/okamoto/socket-to-api/internal/apperrors"
+)
+
+const (
+	// DefaultMaxResponseBytes caps how much of a response body is decoded
+	// when a client doesn't set its own limit
+	DefaultMaxResponseBytes = 10 << 20
+	// maxDrainBytes caps how much unread body is discarded to keep the connection reusable
+	maxDrainBytes = 64 << 10
+)
+
+// ErrResponseTooLarge is returned when a response body exceeds the client's limit
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// decodeJSON streams the response body into a map without buffering it whole.
+// A body larger than maxBytes (DefaultMaxResponseBytes if zero or less)
+// is rejected with ErrResponseTooLarge.
+func decodeJSON(resp *http.Response, maxBytes int64) (map[string]interface{}, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxResponseBytes
+	}
+	body := &io.LimitedReader{R: resp.Body, N: maxBytes + 1}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(body).Decode(&result); err != nil {
+		if body.N <= 0 {
+			return nil, apperrors.Wrap(apperrors.ErrDownstream, "failed to decode response", ErrResponseTooLarge)
+		}
+		return nil, apperrors.Wrap(apperrors.ErrDownstream, "failed to decode response", err)
+	}
+	// A complete document can still end past the limit when it is exactly maxBytes+1 long
+	if body.N <= 0 {
+		return nil, apperrors.Wrap(apperrors.ErrDownstream, "failed to decode response", ErrResponseTooLarge)
+	}
+
+	return result, nil
+}
+
+// closeBody discards a bounded amount of unread body before closing, so a
+// short or rejected read doesn't stop the transport from reusing the connection
+func closeBody(body io.ReadCloser) {
+	io.Copy(io.Discard, io.LimitReader(body, maxDrainBytes))
+	body.Close()
+}
@@ -0,0 +1,71 @@
+package httpclient
+
+import (
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/okamoto/socket-to-api/internal/apperrors"
+)
+
+// writeCAFile stores the test server's certificate as a PEM bundle for TLSOptions.CAFile
+func writeCAFile(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+	body := fmt.Sprintf(`{"data": %q}`, strings.Repeat("x", 4096))
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		maxBytes int64
+		wantErr  error
+	}{
+		{"within default limit", 0, nil},
+		{"over client limit", 1024, ErrResponseTooLarge},
+		{"exactly at client limit", int64(len(body)), nil},
+		{"one byte over client limit", int64(len(body)) - 1, ErrResponseTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewHTTPClientWithTLS(TLSOptions{
+				CAFile:           writeCAFile(t, srv),
+				MaxResponseBytes: tt.maxBytes,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = client.TestHTTPSRequest(srv.URL)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("TestHTTPSRequest() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("TestHTTPSRequest() error = %v, want %v", err, tt.wantErr)
+			}
+			if !errors.Is(err, apperrors.ErrDownstream) {
+				t.Errorf("TestHTTPSRequest() error = %v, want kind %v", err, apperrors.ErrDownstream)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/okamoto/socket-to-api/internal/apperrors"
+)
+
+// StatusError is returned when the API answers with a non-2xx status
+type StatusError struct {
+	StatusCode int
+	// RetryAfter is the delay requested by the server, zero if none was sent
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// StatusRange is an inclusive range of HTTP status codes
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// RetryPolicy decides which API failures are worth retrying
+type RetryPolicy struct {
+	// Retryable lists the status codes that may succeed on a later attempt
+	Retryable []StatusRange
+	// RetryTimeouts controls whether network timeouts are retried
+	RetryTimeouts bool
+}
+
+// DefaultRetryPolicy retries 429, 5xx and network timeouts.
+// Other 4xx responses mean the request itself is wrong and are never retried.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Retryable: []StatusRange{
+			{Min: http.StatusTooManyRequests, Max: http.StatusTooManyRequests},
+			{Min: 500, Max: 599},
+		},
+		RetryTimeouts: true,
+	}
+}
+
+// Classify returns apperrors.ErrTransient if err is worth retrying under
+// this policy and apperrors.ErrPermanent otherwise. A transient or
+// permanent kind already attached to err is kept as is.
+func (p RetryPolicy) Classify(err error) error {
+	switch {
+	case errors.Is(err, apperrors.ErrTransient):
+		return apperrors.ErrTransient
+	case errors.Is(err, apperrors.ErrPermanent):
+		return apperrors.ErrPermanent
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		for _, r := range p.Retryable {
+			if statusErr.StatusCode >= r.Min && statusErr.StatusCode <= r.Max {
+				return apperrors.ErrTransient
+			}
+		}
+		return apperrors.ErrPermanent
+	}
+
+	var netErr net.Error
+	if p.RetryTimeouts && errors.As(err, &netErr) && netErr.Timeout() {
+		return apperrors.ErrTransient
+	}
+	return apperrors.ErrPermanent
+}
+
+// RetryAfter returns the server-requested delay carried by err, if any
+func RetryAfter(err error) (time.Duration, bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return statusErr.RetryAfter, true
+	}
+	return 0, false
+}
+
+// checkStatus turns a non-2xx response into a StatusError
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	return apperrors.Wrap(apperrors.ErrDownstream, "API request failed", &StatusError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	})
+}
+
+// maxRetryAfter caps the delay a server can request, so a bogus or
+// overflowing Retry-After can't stall the caller indefinitely
+const maxRetryAfter = time.Hour
+
+// parseRetryAfter accepts both forms allowed by RFC 9110: delay-seconds and HTTP-date.
+// Delays longer than maxRetryAfter are clamped to it.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		if seconds >= int64(maxRetryAfter/time.Second) {
+			return maxRetryAfter
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return min(d, maxRetryAfter)
+		}
+	}
+	return 0
+}
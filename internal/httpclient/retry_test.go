@@ -0,0 +1,97 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/okamoto/socket-to-api/internal/apperrors"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func statusResponse(code int) *http.Response {
+	return &http.Response{StatusCode: code, Header: http.Header{}}
+}
+
+func TestClassify(t *testing.T) {
+	policy := DefaultRetryPolicy()
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"429", checkStatus(statusResponse(http.StatusTooManyRequests)), apperrors.ErrTransient},
+		{"500", checkStatus(statusResponse(http.StatusInternalServerError)), apperrors.ErrTransient},
+		{"599", checkStatus(statusResponse(599)), apperrors.ErrTransient},
+		{"404", checkStatus(statusResponse(http.StatusNotFound)), apperrors.ErrPermanent},
+		{"net timeout", apperrors.Wrap(apperrors.ErrDownstream, "request failed", timeoutError{}), apperrors.ErrTransient},
+		{"non-status error", errors.New("boom"), apperrors.ErrPermanent},
+		{"already transient", apperrors.Wrap(apperrors.ErrTransient, "lost connection", nil), apperrors.ErrTransient},
+		{"already permanent", apperrors.Wrap(apperrors.ErrPermanent, "bad request", timeoutError{}), apperrors.ErrPermanent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Classify(tt.err); got != tt.want {
+				t.Errorf("Classify(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyTimeoutsDisabled(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	policy.RetryTimeouts = false
+
+	if got := policy.Classify(timeoutError{}); got != apperrors.ErrPermanent {
+		t.Errorf("Classify(timeout) = %v, want %v", got, apperrors.ErrPermanent)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"empty", "", 0, 0},
+		{"seconds", "120", 120 * time.Second, 120 * time.Second},
+		{"zero seconds", "0", 0, 0},
+		{"seconds over cap", "7200", maxRetryAfter, maxRetryAfter},
+		{"seconds overflowing duration", "99999999999", maxRetryAfter, maxRetryAfter},
+		{"seconds overflowing int64", "99999999999999999999", 0, 0},
+		{"http date", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 50 * time.Second, time.Minute},
+		{"far future date", time.Now().Add(48 * time.Hour).UTC().Format(http.TimeFormat), maxRetryAfter, maxRetryAfter},
+		{"past date", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+		{"garbage", "soon", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value); got < tt.min || got > tt.max {
+				t.Errorf("parseRetryAfter(%q) = %v, want between %v and %v", tt.value, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	resp := statusResponse(http.StatusServiceUnavailable)
+	resp.Header.Set("Retry-After", "30")
+
+	got, ok := RetryAfter(checkStatus(resp))
+	if !ok || got != 30*time.Second {
+		t.Errorf("RetryAfter() = %v, %v, want 30s, true", got, ok)
+	}
+	if _, ok := RetryAfter(errors.New("boom")); ok {
+		t.Error("RetryAfter() reported a delay for a non-status error")
+	}
+}
@@ -0,0 +1,57 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	// maxIdleConnsPerHost keeps more than net/http's default of 2 idle
+	// connections per API host, so bursts reuse connections instead of redialing
+	maxIdleConnsPerHost = 16
+	// maxIdleConns caps idle connections across all hosts
+	maxIdleConns = 64
+	// idleConnTimeout closes pooled connections the API has stopped using
+	idleConnTimeout = 90 * time.Second
+)
+
+// newTransport builds the transport shared by every client in this package.
+// It starts from a clone of http.DefaultTransport, so dial and TLS
+// handshake timeouts and HTTP/2 stay in effect alongside the custom TLS
+// config, and sizes the idle pool for repeated calls to the same few API hosts.
+//
+// Outbound traffic honors the standard proxy environment variables:
+// HTTPS_PROXY / HTTP_PROXY (credentials may be given as user:password@host)
+// and NO_PROXY (comma-separated hosts, domain suffixes or CIDRs).
+// HTTPS targets are tunnelled through the proxy with CONNECT, so the
+// custom RootCAs still verify the real server certificate.
+func newTransport(tlsConfig *tls.Config) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = tlsConfig
+	t.MaxIdleConns = maxIdleConns
+	t.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	t.IdleConnTimeout = idleConnTimeout
+	return t
+}
+
+// tuneTransport applies the per-endpoint connection settings on top of
+// newTransport's defaults; zero values keep the defaults
+func (o TLSOptions) tuneTransport(t *http.Transport) {
+	if o.MaxConnsPerHost > 0 {
+		t.MaxConnsPerHost = o.MaxConnsPerHost
+	}
+	if o.DisableKeepAlives {
+		t.DisableKeepAlives = true
+	}
+	if o.DialTimeout > 0 {
+		t.DialContext = (&net.Dialer{Timeout: o.DialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	}
+	if o.ResponseHeaderTimeout > 0 {
+		t.ResponseHeaderTimeout = o.ResponseHeaderTimeout
+	}
+	if o.ExpectContinueTimeout > 0 {
+		t.ExpectContinueTimeout = o.ExpectContinueTimeout
+	}
+}
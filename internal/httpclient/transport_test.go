@@ -0,0 +1,46 @@
+package httpclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClientWithTLSTransportOptions(t *testing.T) {
+	client, err := NewHTTPClientWithTLS(TLSOptions{
+		MaxConnsPerHost:       4,
+		DisableKeepAlives:     true,
+		DialTimeout:           2 * time.Second,
+		ResponseHeaderTimeout: 3 * time.Second,
+		ExpectContinueTimeout: 500 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := client.client.Transport.(*http.Transport)
+
+	if tr.MaxConnsPerHost != 4 || !tr.DisableKeepAlives ||
+		tr.ResponseHeaderTimeout != 3*time.Second || tr.ExpectContinueTimeout != 500*time.Millisecond {
+		t.Errorf("transport options not applied: %+v", tr)
+	}
+	if tr.DialContext == nil {
+		t.Error("DialContext not set")
+	}
+	if !tr.ForceAttemptHTTP2 || tr.MaxIdleConnsPerHost != maxIdleConnsPerHost || tr.Proxy == nil {
+		t.Error("newTransport defaults lost")
+	}
+}
+
+func TestNewHTTPClientWithTLSTransportDefaults(t *testing.T) {
+	client, err := NewHTTPClientWithTLS(TLSOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := client.client.Transport.(*http.Transport)
+	def := http.DefaultTransport.(*http.Transport)
+
+	if tr.MaxConnsPerHost != 0 || tr.DisableKeepAlives ||
+		tr.ResponseHeaderTimeout != def.ResponseHeaderTimeout || tr.ExpectContinueTimeout != def.ExpectContinueTimeout {
+		t.Errorf("zero options changed the transport: %+v", tr)
+	}
+}
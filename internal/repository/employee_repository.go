@@ -6,6 +6,8 @@ import (
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/okamoto/socket-to-api/internal/apperrors"
+	"github.com/okamoto/socket-to-api/internal/database"
 	"github.com/okamoto/socket-to-api/internal/models"
 )
 
@@ -30,20 +32,32 @@ var deleteEmployeeQuery string
 //go:embed sql/queries/test_dual.sql
 var testDualQuery string
 
+// queryer is the part of *sqlx.DB and *sqlx.Tx the repository methods use,
+// so the same methods run either directly or inside WithTx
+type queryer interface {
+	Select(dest interface{}, query string, args ...interface{}) error
+	Get(dest interface{}, query string, args ...interface{}) error
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
 type EmployeeRepository struct {
+	// db begins transactions; it is nil for the repository WithTx hands to fn
 	db *sqlx.DB
+	// q runs the queries: db itself, or the transaction inside WithTx
+	q queryer
 }
 
 func NewEmployeeRepository(db *sqlx.DB) *EmployeeRepository {
-	return &EmployeeRepository{db: db}
+	return &EmployeeRepository{db: db, q: db}
 }
 
 // GetAll retrieves all employees from the database
 func (r *EmployeeRepository) GetAll() ([]models.Employee, error) {
 	var employees []models.Employee
-	err := r.db.Select(&employees, getAllEmployeesQuery)
+	err := r.q.Select(&employees, getAllEmployeesQuery)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all employees: %w", err)
+		return nil, dbError("failed to get all employees", err)
 	}
 	return employees, nil
 }
@@ -51,9 +65,9 @@ func (r *EmployeeRepository) GetAll() ([]models.Employee, error) {
 // GetByID retrieves an employee by ID
 func (r *EmployeeRepository) GetByID(id int) (*models.Employee, error) {
 	var employee models.Employee
-	err := r.db.Get(&employee, getEmployeeByIDQuery, sql.Named("id", id))
+	err := r.q.Get(&employee, getEmployeeByIDQuery, sql.Named("id", id))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get employee by id: %w", err)
+		return nil, dbError("failed to get employee by id", err)
 	}
 	return &employee, nil
 }
@@ -61,44 +75,44 @@ func (r *EmployeeRepository) GetByID(id int) (*models.Employee, error) {
 // GetBySalary retrieves employees with salary >= minSalary
 func (r *EmployeeRepository) GetBySalary(minSalary float64) ([]models.Employee, error) {
 	var employees []models.Employee
-	err := r.db.Select(&employees, getEmployeesBySalaryQuery, sql.Named("min_salary", minSalary))
+	err := r.q.Select(&employees, getEmployeesBySalaryQuery, sql.Named("min_salary", minSalary))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get employees by salary: %w", err)
+		return nil, dbError("failed to get employees by salary", err)
 	}
 	return employees, nil
 }
 
 // Create inserts a new employee
 func (r *EmployeeRepository) Create(firstName, lastName, email string, salary float64) error {
-	_, err := r.db.Exec(insertEmployeeQuery,
+	_, err := r.q.Exec(insertEmployeeQuery,
 		sql.Named("first_name", firstName),
 		sql.Named("last_name", lastName),
 		sql.Named("email", email),
 		sql.Named("salary", salary),
 	)
 	if err != nil {
-		return fmt.Errorf("failed to insert employee: %w", err)
+		return dbError("failed to insert employee", err)
 	}
 	return nil
 }
 
 // UpdateSalary updates an employee's salary
 func (r *EmployeeRepository) UpdateSalary(id int, newSalary float64) error {
-	result, err := r.db.Exec(updateEmployeeSalaryQuery,
+	result, err := r.q.Exec(updateEmployeeSalaryQuery,
 		sql.Named("id", id),
 		sql.Named("salary", newSalary),
 	)
 	if err != nil {
-		return fmt.Errorf("failed to update employee salary: %w", err)
+		return dbError("failed to update employee salary", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return dbError("failed to get rows affected", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("employee with id %d not found", id)
+		return apperrors.Wrap(apperrors.ErrPermanent, fmt.Sprintf("employee with id %d not found", id), nil)
 	}
 
 	return nil
@@ -106,18 +120,18 @@ func (r *EmployeeRepository) UpdateSalary(id int, newSalary float64) error {
 
 // Delete removes an employee by ID
 func (r *EmployeeRepository) Delete(id int) error {
-	result, err := r.db.Exec(deleteEmployeeQuery, sql.Named("id", id))
+	result, err := r.q.Exec(deleteEmployeeQuery, sql.Named("id", id))
 	if err != nil {
-		return fmt.Errorf("failed to delete employee: %w", err)
+		return dbError("failed to delete employee", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return dbError("failed to get rows affected", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("employee with id %d not found", id)
+		return apperrors.Wrap(apperrors.ErrPermanent, fmt.Sprintf("employee with id %d not found", id), nil)
 	}
 
 	return nil
@@ -127,9 +141,9 @@ func (r *EmployeeRepository) Delete(id int) error {
 func (r *EmployeeRepository) TestDual() (map[string]interface{}, error) {
 	result := make(map[string]interface{})
 
-	rows, err := r.db.Query(testDualQuery)
+	rows, err := r.q.Query(testDualQuery)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query DUAL: %w", err)
+		return nil, dbError("failed to query DUAL", err)
 	}
 	defer rows.Close()
 
@@ -140,7 +154,7 @@ func (r *EmployeeRepository) TestDual() (map[string]interface{}, error) {
 
 		err = rows.Scan(&currentDate, &currentUser, &message)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan DUAL result: %w", err)
+			return nil, dbError("failed to scan DUAL result", err)
 		}
 
 		result["current_date"] = currentDate
@@ -150,3 +164,8 @@ func (r *EmployeeRepository) TestDual() (map[string]interface{}, error) {
 
 	return result, nil
 }
+
+// dbError tags a database failure with its retry classification
+func dbError(msg string, err error) error {
+	return apperrors.Wrap(database.ErrorKind(err), msg, err)
+}
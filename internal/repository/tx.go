@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/okamoto/socket-to-api/internal/apperrors"
+	"github.com/okamoto/socket-to-api/internal/database"
+)
+
+const (
+	// maxTxAttempts bounds how many times WithTx runs a transaction
+	maxTxAttempts = 3
+	// txRetryBackoff is multiplied by the attempt number between retries
+	txRetryBackoff = 100 * time.Millisecond
+)
+
+// ErrCommitUnknown is returned when the connection breaks during COMMIT,
+// so the transaction may or may not have been applied
+var ErrCommitUnknown = errors.New("commit outcome unknown")
+
+// WithTx runs fn inside a transaction and commits if fn returns nil.
+// fn receives a repository bound to the transaction, so the usual methods
+// (Create, UpdateSalary, Delete, ...) run inside it; it must not call
+// WithTx again.
+//
+// If begin or fn fails with a transient error (ORA-00060 deadlock,
+// ORA-08177 serialization failure, dropped connection) the transaction is
+// rolled back and fn is run again from the start, so any side effects fn
+// has outside the transaction must be safe to repeat.
+//
+// A failed COMMIT is never retried. It is returned as apperrors.ErrPermanent,
+// wrapping ErrCommitUnknown when the connection was lost and the commit may
+// already have landed.
+func (r *EmployeeRepository) WithTx(fn func(tx *EmployeeRepository) error) error {
+	if r.db == nil {
+		return apperrors.Wrap(apperrors.ErrPermanent, "nested transactions are not supported", nil)
+	}
+
+	var err error
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = r.runTx(fn)
+		if err == nil || !errors.Is(err, apperrors.ErrTransient) {
+			return err
+		}
+		if attempt < maxTxAttempts {
+			time.Sleep(time.Duration(attempt) * txRetryBackoff)
+		}
+	}
+	return err
+}
+
+// runTx makes a single attempt at fn, classifying any untyped error it returns
+func (r *EmployeeRepository) runTx(fn func(tx *EmployeeRepository) error) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return dbError("failed to begin transaction", err)
+	}
+	// Rolls back on error or panic in fn; a no-op once Commit has run
+	defer tx.Rollback()
+
+	if err := fn(&EmployeeRepository{q: tx}); err != nil {
+		if apperrors.KindOf(err) == nil {
+			return dbError("transaction failed", err)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		if database.ConnectionLost(err) {
+			err = fmt.Errorf("%w: %w", ErrCommitUnknown, err)
+		}
+		return apperrors.Wrap(apperrors.ErrPermanent, "failed to commit transaction", err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/okamoto/socket-to-api/internal/apperrors"
+	"github.com/sijms/go-ora/v2/network"
+)
+
+// fakeDB is a minimal database/sql driver that counts transaction calls
+type fakeDB struct {
+	commitErr error
+	commits   int
+	rollbacks int
+	execs     int
+}
+
+func (d *fakeDB) repo() *EmployeeRepository {
+	return NewEmployeeRepository(sqlx.NewDb(sql.OpenDB(d), "oracle"))
+}
+
+func (d *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{d}, nil }
+func (d *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ d *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error)      { return fakeStmt{c.d}, nil }
+func (c fakeConn) Close() error                             { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                { return fakeTx{c.d}, nil }
+func (c fakeConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+type fakeTx struct{ d *fakeDB }
+
+func (t fakeTx) Commit() error {
+	t.d.commits++
+	return t.d.commitErr
+}
+
+func (t fakeTx) Rollback() error {
+	t.d.rollbacks++
+	return nil
+}
+
+type fakeStmt struct{ d *fakeDB }
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.d.execs++
+	return driver.RowsAffected(1), nil
+}
+
+// ExecContext accepts sql.Named arguments, which plain Exec can't receive
+func (s fakeStmt) ExecContext(context.Context, []driver.NamedValue) (driver.Result, error) {
+	return s.Exec(nil)
+}
+
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported by fakeDB")
+}
+
+func TestWithTxRunsRepositoryMethods(t *testing.T) {
+	db := &fakeDB{}
+	err := db.repo().WithTx(func(tx *EmployeeRepository) error {
+		return tx.UpdateSalary(1, 75000)
+	})
+	if err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+	if db.execs != 1 || db.commits != 1 {
+		t.Errorf("execs = %d, commits = %d, want 1 and 1", db.execs, db.commits)
+	}
+}
+
+func TestWithTxRetriesTransientFnErrors(t *testing.T) {
+	db := &fakeDB{}
+	calls := 0
+	err := db.repo().WithTx(func(tx *EmployeeRepository) error {
+		if calls++; calls == 1 {
+			return network.NewOracleError(60) // deadlock
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+	if calls != 2 || db.rollbacks < 1 || db.commits != 1 {
+		t.Errorf("calls = %d, rollbacks = %d, commits = %d", calls, db.rollbacks, db.commits)
+	}
+}
+
+func TestWithTxDoesNotRetryCommit(t *testing.T) {
+	tests := []struct {
+		name        string
+		commitErr   error
+		wantUnknown bool
+	}{
+		{"connection lost", io.EOF, true},
+		{"lost session", network.NewOracleError(3113), true},
+		{"deadlock", network.NewOracleError(60), false},
+		{"serialization failure", network.NewOracleError(8177), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{commitErr: tt.commitErr}
+			calls := 0
+			err := db.repo().WithTx(func(tx *EmployeeRepository) error {
+				calls++
+				return nil
+			})
+			if !errors.Is(err, apperrors.ErrPermanent) {
+				t.Fatalf("WithTx() error = %v, want %v", err, apperrors.ErrPermanent)
+			}
+			if got := errors.Is(err, ErrCommitUnknown); got != tt.wantUnknown {
+				t.Errorf("errors.Is(%v, ErrCommitUnknown) = %v, want %v", err, got, tt.wantUnknown)
+			}
+			if calls != 1 || db.commits != 1 {
+				t.Errorf("calls = %d, commits = %d, want 1 and 1", calls, db.commits)
+			}
+		})
+	}
+}
+
+func TestWithTxRollsBackOnPanic(t *testing.T) {
+	db := &fakeDB{}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("WithTx() swallowed the panic")
+			}
+		}()
+		db.repo().WithTx(func(tx *EmployeeRepository) error {
+			panic("boom")
+		})
+	}()
+	if db.rollbacks != 1 || db.commits != 0 {
+		t.Errorf("rollbacks = %d, commits = %d, want 1 and 0", db.rollbacks, db.commits)
+	}
+}
+
+func TestWithTxRejectsNesting(t *testing.T) {
+	db := &fakeDB{}
+	var nestedErr error
+	db.repo().WithTx(func(tx *EmployeeRepository) error {
+		nestedErr = tx.WithTx(func(*EmployeeRepository) error { return nil })
+		return nil
+	})
+	if !errors.Is(nestedErr, apperrors.ErrPermanent) {
+		t.Errorf("nested WithTx() error = %v, want %v", nestedErr, apperrors.ErrPermanent)
+	}
+}
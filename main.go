@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"strings"
 
 	"github.com/okamoto/socket-to-api/internal/database"
 	"github.com/okamoto/socket-to-api/internal/httpclient"
@@ -14,6 +16,9 @@ import (
 func main() {
 	fmt.Println("=== Oracle DB POC with go-ora + sqlx ===")
 
+	// httpclient reports cert source selection through slog; LOG_LEVEL=debug shows every attempt
+	httpclient.SetLogger(newLogger(getEnv("LOG_LEVEL", "info")))
+
 	// Database configuration
 	cfg := database.Config{
 		Host:     getEnv("DB_HOST", "localhost"),
@@ -21,6 +26,9 @@ func main() {
 		Service:  getEnv("DB_SERVICE", "XEPDB1"),
 		Username: getEnv("DB_USER", "app"),
 		Password: getEnv("DB_PASSWORD", "app"),
+		// Comma-separated host:port list tried after DB_HOST (RAC / Data Guard)
+		FailoverHosts:      splitList(getEnv("DB_FAILOVER_HOSTS", "")),
+		MaxConnectAttempts: 5,
 	}
 
 	// Connect to database
@@ -86,8 +94,10 @@ func main() {
 	printJSON("DUAL Query Result", dualResult)
 
 	// Test 7: Update employee salary
-	fmt.Println("\n8. Updating employee salary (ID=1, new salary=75000)...")
-	err = repo.UpdateSalary(1, 75000)
+	fmt.Println("\n8. Updating employee salary (ID=1, new salary=75000) in a transaction...")
+	err = repo.WithTx(func(tx *repository.EmployeeRepository) error {
+		return tx.UpdateSalary(1, 75000)
+	})
 	if err != nil {
 		log.Fatalf("Failed to update salary: %v", err)
 	}
@@ -252,6 +262,25 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func newLogger(level string) *slog.Logger {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		log.Printf("Invalid LOG_LEVEL %q, using info", level)
+		lvl = slog.LevelInfo
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: lvl}))
+}
+
 func printJSON(title string, v interface{}) {
 	fmt.Printf("\n--- %s ---\n", title)
 	data, err := json.MarshalIndent(v, "", "  ")